@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"net/url"
 	"os"
 	"path"
 	"regexp"
@@ -16,7 +15,10 @@ import (
 	dockerapi "github.com/fsouza/go-dockerclient"
 )
 
-var serviceIDPattern = regexp.MustCompile(`^(.+?):([a-zA-Z0-9][a-zA-Z0-9_.-]+):[0-9]+(?::udp)?$`)
+// the optional trailing segment matches the network-name suffix newService
+// appends for network-scoped registrations (":8080:overlay1"), so those
+// services still parse as "registered by us" in Sync's dangling-cleanup.
+var serviceIDPattern = regexp.MustCompile(`^(.+?):([a-zA-Z0-9][a-zA-Z0-9_.-]+):[0-9]+(?::[a-zA-Z0-9][a-zA-Z0-9_.-]*)?(?::udp)?$`)
 var ec2internalpattern = regexp.MustCompile(`ip-\S+\.ec2\.internal`)
 var consulPattern = regexp.MustCompile(`((^.+/)|^)consul(:.+)?$`)
 var proxyToPortPattern = regexp.MustCompile(`-service-addr\s*=.+:(\d+)`)
@@ -26,29 +28,35 @@ var destinationServicePattern = regexp.MustCompile(`-service\s*=(.+)`)
 type Bridge struct {
 	sync.Mutex
 	registry       RegistryAdapter
-	docker         *dockerapi.Client
+	runtime        ContainerRuntime
 	services       map[string][]*Service
 	deadContainers map[string]*DeadContainer
+	unhealthy      map[string]bool
 	config         Config
 }
 
-func New(docker *dockerapi.Client, adapterUri string, config Config) (*Bridge, error) {
-	uri, err := url.Parse(adapterUri)
+// New builds a Bridge. adapterUris is a comma-separated list of registry
+// adapter URIs; a single URI keeps the previous single-adapter behavior,
+// while more than one fans Register/Deregister/Refresh/Services/Ping out to
+// a multiAdapter so Consul, etcd, etc. can be kept in sync from one process.
+func New(runtimeUri string, adapterUris string, config Config) (*Bridge, error) {
+	runtime, err := NewContainerRuntime(runtimeUri)
 	if err != nil {
-		return nil, errors.New("bad adapter uri: " + adapterUri)
+		return nil, err
 	}
-	factory, found := AdapterFactories.Lookup(uri.Scheme)
-	if !found {
-		return nil, errors.New("unrecognized adapter: " + adapterUri)
+
+	registry, err := newRegistryAdapter(adapterUris)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("Using", uri.Scheme, "adapter:", uri)
 	return &Bridge{
-		docker:         docker,
+		runtime:        runtime,
 		config:         config,
-		registry:       factory.New(uri),
+		registry:       registry,
 		services:       make(map[string][]*Service),
 		deadContainers: make(map[string]*DeadContainer),
+		unhealthy:      make(map[string]bool),
 	}, nil
 }
 
@@ -83,6 +91,12 @@ func (b *Bridge) Refresh() {
 
 	for containerId, services := range b.services {
 		for _, service := range services {
+			if b.unhealthy[service.ID] {
+				// a health_status event marked this failing; skip refreshing
+				// it so the registry's own TTL lapses instead of being kept
+				// alive by this periodic safety net.
+				continue
+			}
 			err := b.registry.Refresh(service)
 			if err != nil {
 				log.Println("refresh failed:", service.ID, err)
@@ -97,7 +111,7 @@ func (b *Bridge) Sync(quiet bool) {
 	b.Lock()
 	defer b.Unlock()
 
-	containers, err := b.docker.ListContainers(dockerapi.ListContainersOptions{})
+	containers, err := b.runtime.ListContainers()
 	if err != nil && quiet {
 		log.Println("error listing containers, skipping sync")
 		return
@@ -114,6 +128,12 @@ func (b *Bridge) Sync(quiet bool) {
 			b.add(listing.ID, quiet)
 		} else {
 			for _, service := range services {
+				if b.unhealthy[service.ID] {
+					// don't re-register a service a health_status event
+					// marked failing; let its TTL lapse the way Refresh()
+					// already does.
+					continue
+				}
 				err := b.registry.Register(service)
 				if err != nil {
 					log.Println("sync register failed:", service, err)
@@ -127,8 +147,7 @@ func (b *Bridge) Sync(quiet bool) {
 	if b.config.Cleanup {
 		// Remove services if its corresponding container is not running
 		log.Println("Listing non-exited containers")
-		filters := map[string][]string{"status": {"created", "restarting", "running", "paused"}}
-		nonExitedContainers, err := b.docker.ListContainers(dockerapi.ListContainersOptions{Filters: filters})
+		nonExitedContainers, err := b.runtime.ListContainers("created", "restarting", "running", "paused")
 		if err != nil {
 			log.Println("error listing nonExitedContainers, skipping sync", err)
 			return
@@ -198,7 +217,7 @@ func (b *Bridge) add(containerId string, quiet bool) {
 		return
 	}
 
-	container, err := b.docker.InspectContainer(containerId)
+	container, err := b.runtime.InspectContainer(containerId)
 	if err != nil {
 		log.Println("unable to inspect container:", containerId[:12], err)
 		return
@@ -235,27 +254,93 @@ func (b *Bridge) add(containerId string, quiet bool) {
 
 	isGroup := len(servicePorts) > 1
 	for _, port := range servicePorts {
-		service := b.newService(port, isGroup)
-		if service == nil {
+		services := b.newServices(port, isGroup)
+		if len(services) == 0 {
 			if !quiet {
 				log.Println("ignored:", container.ID[:12], "service on port", port.ExposedPort)
 			}
 			continue
 		}
-		if b.config.Awsvpc && b.config.HostIp != "" && b.config.HostIp != service.IP {
-			log.Println("ignored:", container.ID[:12], "service on port", port.ExposedPort, fmt.Sprintf("container awsvpc ip %s does not match registrator ip %s", service.IP, b.config.HostIp))
+		for _, service := range services {
+			if b.config.Awsvpc && b.config.HostIp != "" && b.config.HostIp != service.IP {
+				log.Println("ignored:", container.ID[:12], "service on port", port.ExposedPort, fmt.Sprintf("container awsvpc ip %s does not match registrator ip %s", service.IP, b.config.HostIp))
+			}
+			err := b.registry.Register(service)
+			if err != nil {
+				log.Println("register failed:", service, err)
+				continue
+			}
+			b.services[container.ID] = append(b.services[container.ID], service)
+			log.Println("added:", container.ID[:12], service.ID)
+		}
+	}
+}
+
+// newServices resolves one ServicePort into the *Service(s) it should be
+// registered as. Most ports yield a single service, but a container attached
+// to several libnetwork networks can ask (via the "network" metadata key or
+// the -network flag) to be registered under more than one of them: setting
+// SERVICE_NETWORKS_MULTI=true registers one service per matching network
+// instead of just the highest-priority match.
+func (b *Bridge) newServices(port ServicePort, isgroup bool) []*Service {
+	container := port.container
+	metadata, _ := serviceMetaData(container.Config, port.ExposedPort)
+
+	networkNames := serviceNetworks(metadata, b.config.Networks)
+	if len(networkNames) == 0 {
+		if service := b.newService(port, isgroup, ""); service != nil {
+			return []*Service{service}
 		}
-		err := b.registry.Register(service)
-		if err != nil {
-			log.Println("register failed:", service, err)
+		return nil
+	}
+
+	if mapDefault(metadata, "networks_multi", "") != "true" {
+		name := firstMatchingNetwork(container, networkNames)
+		if service := b.newService(port, isgroup, name); service != nil {
+			return []*Service{service}
+		}
+		return nil
+	}
+
+	var services []*Service
+	for _, name := range networkNames {
+		if _, ok := container.NetworkSettings.Networks[name]; !ok {
 			continue
 		}
-		b.services[container.ID] = append(b.services[container.ID], service)
-		log.Println("added:", container.ID[:12], service.ID)
+		if service := b.newService(port, isgroup, name); service != nil {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// serviceNetworks returns the libnetwork networks a service's IP should be
+// resolved from, in priority order: a per-container "network" metadata
+// (SERVICE_NETWORK) override if set, otherwise the -network flag default.
+func serviceNetworks(metadata map[string]string, defaults []string) []string {
+	if raw := mapDefault(metadata, "network", ""); raw != "" {
+		return strings.Split(raw, ",")
+	}
+	return defaults
+}
+
+// firstMatchingNetwork returns the first of names the container is actually
+// attached to, or "" if none match.
+func firstMatchingNetwork(container *dockerapi.Container, names []string) string {
+	for _, name := range names {
+		if _, ok := container.NetworkSettings.Networks[name]; ok {
+			return name
+		}
 	}
+	return ""
 }
 
-func (b *Bridge) newService(port ServicePort, isgroup bool) *Service {
+// newService builds the Service for port, resolving its IP from the named
+// libnetwork network when networkName is non-empty instead of the usual
+// HostIP / EC2-hostname resolution. An IP taken from the UseIpFromLabel label
+// or from a "container:" NetworkMode peer is applied afterwards and still
+// overrides the network's IP.
+func (b *Bridge) newService(port ServicePort, isgroup bool, networkName string) *Service {
 	container := port.container
 	defaultName := strings.Split(path.Base(container.Config.Image), ":")[0]
 
@@ -342,6 +427,21 @@ func (b *Bridge) newService(port ServicePort, isgroup bool) *Service {
 	}
 	service.Port = p
 
+	// A selected libnetwork network overrides the IP (and, for internal
+	// services, the port) resolved above, and distinguishes the resulting
+	// service ID so the same container can register once per network.
+	if networkName != "" {
+		netInfo, ok := container.NetworkSettings.Networks[networkName]
+		if !ok {
+			return nil
+		}
+		service.IP = netInfo.IPAddress
+		if b.config.Internal == true {
+			service.Port, _ = strconv.Atoi(port.ExposedPort)
+		}
+		service.ID = service.ID + ":" + networkName
+	}
+
 	// set consul connect proxy port if defined in metadata
 	proxyport := mapDefault(metadata, "proxyport", "")
 	if proxyport != "" {
@@ -372,7 +472,7 @@ func (b *Bridge) newService(port ServicePort, isgroup bool) *Service {
 		if strings.HasPrefix(networkMode, "container:") {
 			networkContainerId := strings.Split(networkMode, ":")[1]
 			log.Println(service.Name + ": detected container NetworkMode, linked to: " + networkContainerId[:12])
-			networkContainer, err := b.docker.InspectContainer(networkContainerId)
+			networkContainer, err := b.runtime.InspectContainer(networkContainerId)
 			if err != nil {
 				log.Println("unable to inspect network container:", networkContainerId[:12], err)
 			} else {
@@ -400,6 +500,12 @@ func (b *Bridge) newService(port ServicePort, isgroup bool) *Service {
 	id := mapDefault(metadata, "id", "")
 	if id != "" {
 		service.ID = id
+		if networkName != "" {
+			// re-apply the network suffix the id override just discarded, so
+			// SERVICE_NETWORKS_MULTI still yields one distinct ID per network
+			// instead of every network clobbering the same registry entry.
+			service.ID = service.ID + ":" + networkName
+		}
 	}
 
 	delete(metadata, "id")
@@ -436,6 +542,9 @@ func (b *Bridge) remove(containerId string, deregister bool) {
 		// need to stop the refreshing, but can't delete it yet
 		b.deadContainers[containerId] = &DeadContainer{b.config.RefreshTtl, b.services[containerId]}
 	}
+	for _, service := range b.services[containerId] {
+		delete(b.unhealthy, service.ID)
+	}
 	delete(b.services, containerId)
 }
 
@@ -446,9 +555,9 @@ func (b *Bridge) shouldRemove(containerId string) bool {
 	if b.config.DeregisterCheck == "always" {
 		return true
 	}
-	container, err := b.docker.InspectContainer(containerId)
-	if _, ok := err.(*dockerapi.NoSuchContainer); ok {
-		// the container has already been removed from Docker
+	container, err := b.runtime.InspectContainer(containerId)
+	if errors.Is(err, ErrContainerNotFound) {
+		// the container has already been removed from the runtime
 		// e.g. probabably run with "--rm" to remove immediately
 		// so its exit code is not accessible
 		log.Printf("registrator: container %v was removed, could not fetch exit code", containerId[:12])
@@ -0,0 +1,100 @@
+package bridge
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+)
+
+// EventLoop subscribes to the runtime's event stream and reacts to container
+// lifecycle and network changes as they happen, instead of waiting for the
+// next -resync tick. It blocks until ctx is cancelled or the event channel
+// closes. The periodic Sync/Refresh loop keeps running alongside it as a
+// safety net for events the stream drops.
+func (b *Bridge) EventLoop(ctx context.Context) error {
+	events := make(chan *dockerapi.APIEvents, 100)
+	if err := b.runtime.AddEventListener(events); err != nil {
+		return err
+	}
+	defer b.runtime.RemoveEventListener(events)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			b.handleEvent(event)
+		}
+	}
+}
+
+func (b *Bridge) handleEvent(event *dockerapi.APIEvents) {
+	switch {
+	case event.Action == "start":
+		b.Add(event.ID)
+	case event.Action == "die" || event.Action == "destroy":
+		b.RemoveOnExit(event.ID)
+	case strings.HasPrefix(event.Action, "health_status"):
+		b.handleHealthStatus(event)
+	case event.Type == "network" && (event.Action == "connect" || event.Action == "disconnect"):
+		b.handleNetworkChange(event)
+	}
+}
+
+// handleNetworkChange re-runs newService for a container that just joined or
+// left a Docker network, so a newly attached overlay IP (or the loss of one)
+// is reflected without waiting for resync.
+func (b *Bridge) handleNetworkChange(event *dockerapi.APIEvents) {
+	containerId := event.Actor.Attributes["container"]
+	if containerId == "" {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if services := b.services[containerId]; services != nil {
+		for _, service := range services {
+			if err := b.registry.Deregister(service); err != nil {
+				log.Println("deregister failed:", service.ID, err)
+			}
+			delete(b.unhealthy, service.ID)
+		}
+		delete(b.services, containerId)
+	}
+
+	log.Println("network", event.Action+":", containerId[:12], "re-evaluating services")
+	b.add(containerId, true)
+}
+
+// handleHealthStatus flips the TTL refresh for a container's services based
+// on its Docker healthcheck: a passing check keeps refreshing the
+// registration, a failing one stops, letting the registry's TTL expire it.
+func (b *Bridge) handleHealthStatus(event *dockerapi.APIEvents) {
+	healthy := !strings.Contains(event.Action, "unhealthy")
+
+	b.Lock()
+	defer b.Unlock()
+
+	services := b.services[event.ID]
+	if services == nil {
+		return
+	}
+
+	for _, service := range services {
+		if !healthy {
+			log.Println("health check failing, letting TTL lapse:", service.ID)
+			b.unhealthy[service.ID] = true
+			continue
+		}
+		delete(b.unhealthy, service.ID)
+		if err := b.registry.Refresh(service); err != nil {
+			log.Println("health refresh failed:", service.ID, err)
+		}
+	}
+}
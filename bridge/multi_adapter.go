@@ -0,0 +1,160 @@
+package bridge
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// newRegistryAdapter builds the RegistryAdapter New uses from a
+// comma-separated list of adapter URIs, reusing the existing
+// AdapterFactories.Lookup machinery for each one. A single URI returns that
+// adapter directly; more than one returns a multiAdapter fanning out to all
+// of them.
+func newRegistryAdapter(adapterUris string) (RegistryAdapter, error) {
+	var adapters []RegistryAdapter
+	for _, raw := range strings.Split(adapterUris, ",") {
+		raw = strings.TrimSpace(raw)
+		uri, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.New("bad adapter uri: " + raw)
+		}
+		factory, found := AdapterFactories.Lookup(uri.Scheme)
+		if !found {
+			return nil, errors.New("unrecognized adapter: " + raw)
+		}
+
+		log.Println("Using", uri.Scheme, "adapter:", uri)
+		adapters = append(adapters, factory.New(uri))
+	}
+
+	if len(adapters) == 1 {
+		return adapters[0], nil
+	}
+	return newMultiAdapter(adapters), nil
+}
+
+// multiAdapter implements RegistryAdapter by fanning Register/Deregister/
+// Refresh/Services/Ping out to several backend adapters, e.g. Consul and
+// etcd at once. A failure on one backend is logged but never stops the
+// others. owners records, per service ID, every adapter that's actually seen
+// that service (via a successful Register or via Services()). Deregister and
+// Refresh target that set when it's non-empty, so a service registered on
+// every backend is kept alive/removed on every backend, while an entry
+// discovered via Services() that only one backend reports (the case
+// Sync's dangling-cleanup runs into) is only touched on the backend that
+// owns it. An empty set falls back to fanning out to everything, since that
+// only happens before ownership has been learned at all (e.g. just after a
+// restart).
+type multiAdapter struct {
+	sync.Mutex
+	adapters []RegistryAdapter
+	owners   map[string][]RegistryAdapter
+}
+
+func newMultiAdapter(adapters []RegistryAdapter) *multiAdapter {
+	return &multiAdapter{
+		adapters: adapters,
+		owners:   make(map[string][]RegistryAdapter),
+	}
+}
+
+func (m *multiAdapter) Ping() error {
+	var lastErr error
+	for _, adapter := range m.adapters {
+		if err := adapter.Ping(); err != nil {
+			log.Println("multi-adapter ping failed:", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (m *multiAdapter) Register(service *Service) error {
+	var lastErr error
+	for _, adapter := range m.adapters {
+		if err := adapter.Register(service); err != nil {
+			log.Println("multi-adapter register failed:", err)
+			lastErr = err
+			continue
+		}
+		m.addOwner(service.ID, adapter)
+	}
+	return lastErr
+}
+
+func (m *multiAdapter) Deregister(service *Service) error {
+	targets := m.ownersOrAll(service.ID)
+
+	var lastErr error
+	for _, adapter := range targets {
+		if err := adapter.Deregister(service); err != nil {
+			log.Println("multi-adapter deregister failed:", err)
+			lastErr = err
+		}
+	}
+	m.clearOwner(service.ID)
+	return lastErr
+}
+
+func (m *multiAdapter) Refresh(service *Service) error {
+	var lastErr error
+	for _, adapter := range m.ownersOrAll(service.ID) {
+		if err := adapter.Refresh(service); err != nil {
+			log.Println("multi-adapter refresh failed:", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Services unions every backend's services, recording which adapter(s)
+// reported each one so a subsequent Deregister (e.g. from Sync's
+// dangling-cleanup) routes only to the adapter(s) that actually have it.
+func (m *multiAdapter) Services() ([]*Service, error) {
+	var union []*Service
+	var lastErr error
+	for _, adapter := range m.adapters {
+		services, err := adapter.Services()
+		if err != nil {
+			log.Println("multi-adapter services failed:", err)
+			lastErr = err
+			continue
+		}
+		for _, service := range services {
+			m.addOwner(service.ID, adapter)
+			union = append(union, service)
+		}
+	}
+	return union, lastErr
+}
+
+func (m *multiAdapter) addOwner(id string, adapter RegistryAdapter) {
+	m.Lock()
+	defer m.Unlock()
+	for _, existing := range m.owners[id] {
+		if existing == adapter {
+			return
+		}
+	}
+	m.owners[id] = append(m.owners[id], adapter)
+}
+
+// ownersOrAll returns the adapters known to have this service ID, or every
+// adapter if none are recorded yet.
+func (m *multiAdapter) ownersOrAll(id string) []RegistryAdapter {
+	m.Lock()
+	defer m.Unlock()
+	if owners := m.owners[id]; len(owners) > 0 {
+		return owners
+	}
+	return m.adapters
+}
+
+func (m *multiAdapter) clearOwner(id string) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.owners, id)
+}
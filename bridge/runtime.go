@@ -0,0 +1,54 @@
+package bridge
+
+import (
+	"errors"
+	"net/url"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+)
+
+// ContainerRuntime abstracts the container engine Bridge talks to, so that
+// Docker and Podman hosts can be treated the same way. Implementations
+// surface dockerapi's own types (Container, APIContainers, APIEvents) as the
+// common currency, since that's what the rest of Bridge (Awsvpc handling,
+// Connect-proxy detection, label-driven overrides) already consumes.
+type ContainerRuntime interface {
+	// ListContainers lists containers, optionally restricted to the given
+	// status values (e.g. "running", "created", "restarting", "paused").
+	// No statuses means "running containers only", matching the previous
+	// bare docker.ListContainers(dockerapi.ListContainersOptions{}) call.
+	ListContainers(statuses ...string) ([]dockerapi.APIContainers, error)
+	InspectContainer(id string) (*dockerapi.Container, error)
+	AddEventListener(listener chan *dockerapi.APIEvents) error
+	RemoveEventListener(listener chan *dockerapi.APIEvents) error
+}
+
+// ErrContainerNotFound is what InspectContainer implementations wrap their
+// backend-specific "no such container" error in, so callers like
+// Bridge.shouldRemove can detect an already-removed container with
+// errors.Is instead of depending on a specific runtime's error type.
+var ErrContainerNotFound = errors.New("container not found")
+
+// NewContainerRuntime builds a ContainerRuntime from a runtime URI. The
+// scheme selects the backend:
+//
+//	docker:///var/run/docker.sock   (or no scheme at all)
+//	podman:///run/podman/podman.sock
+//
+// Docker remains the default so existing deployments that pass a bare
+// DOCKER_HOST-style value keep working unchanged.
+func NewContainerRuntime(runtimeUri string) (ContainerRuntime, error) {
+	uri, err := url.Parse(runtimeUri)
+	if err != nil {
+		return nil, errors.New("bad runtime uri: " + runtimeUri)
+	}
+
+	switch uri.Scheme {
+	case "", "docker", "unix", "tcp", "fd":
+		return newDockerRuntime(runtimeUri)
+	case "podman":
+		return newPodmanRuntime(runtimeUri)
+	default:
+		return nil, errors.New("unrecognized runtime: " + runtimeUri)
+	}
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+)
+
+// dockerRuntime implements ContainerRuntime against a real Docker daemon via
+// go-dockerclient. This is the backend registrator has always used.
+type dockerRuntime struct {
+	client *dockerapi.Client
+}
+
+func newDockerRuntime(uri string) (ContainerRuntime, error) {
+	client, err := dockerapi.NewClient(dockerEndpoint(uri))
+	if err != nil {
+		return nil, err
+	}
+	return &dockerRuntime{client: client}, nil
+}
+
+// dockerEndpoint turns a runtime URI into an endpoint go-dockerclient's
+// dialer can use. A bare docker:// (or no scheme at all) names a Unix
+// socket path, same as the historical DOCKER_HOST default; tcp://, unix://
+// and fd:// URIs already carry a scheme go-dockerclient understands and
+// pass through unchanged.
+func dockerEndpoint(uri string) string {
+	switch {
+	case strings.HasPrefix(uri, "docker://"):
+		return "unix://" + strings.TrimPrefix(uri, "docker://")
+	case strings.Contains(uri, "://"):
+		return uri
+	default:
+		return "unix://" + uri
+	}
+}
+
+func (d *dockerRuntime) ListContainers(statuses ...string) ([]dockerapi.APIContainers, error) {
+	opts := dockerapi.ListContainersOptions{}
+	if len(statuses) > 0 {
+		opts.Filters = map[string][]string{"status": statuses}
+	}
+	return d.client.ListContainers(opts)
+}
+
+func (d *dockerRuntime) InspectContainer(id string) (*dockerapi.Container, error) {
+	container, err := d.client.InspectContainer(id)
+	if _, ok := err.(*dockerapi.NoSuchContainer); ok {
+		return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, err)
+	}
+	return container, err
+}
+
+func (d *dockerRuntime) AddEventListener(listener chan *dockerapi.APIEvents) error {
+	return d.client.AddEventListener(listener)
+}
+
+func (d *dockerRuntime) RemoveEventListener(listener chan *dockerapi.APIEvents) error {
+	return d.client.RemoveEventListener(listener)
+}
@@ -0,0 +1,184 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+
+	"github.com/containers/podman/v5/libpod/define"
+	"github.com/containers/podman/v5/pkg/bindings"
+	"github.com/containers/podman/v5/pkg/bindings/containers"
+	"github.com/containers/podman/v5/pkg/bindings/system"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+)
+
+// podmanRuntime implements ContainerRuntime against a Podman REST socket, for
+// rootless Podman hosts and CRI-O-only nodes where the Docker socket doesn't
+// exist. Responses are translated into go-dockerclient's own types so the
+// rest of Bridge doesn't need to know which engine it's talking to.
+type podmanRuntime struct {
+	conn context.Context
+}
+
+func newPodmanRuntime(uri string) (ContainerRuntime, error) {
+	conn, err := bindings.NewConnection(context.Background(), podmanConnectionUri(uri))
+	if err != nil {
+		return nil, err
+	}
+	return &podmanRuntime{conn: conn}, nil
+}
+
+// podmanConnectionUri rewrites our podman:// runtime scheme into the unix://
+// scheme Podman's bindings package actually understands; bindings.NewConnection
+// has no notion of "podman://", only unix://, tcp:// and ssh://.
+func podmanConnectionUri(uri string) string {
+	return "unix://" + strings.TrimPrefix(uri, "podman://")
+}
+
+func (p *podmanRuntime) ListContainers(statuses ...string) ([]dockerapi.APIContainers, error) {
+	// No statuses means "running containers only" (see the ContainerRuntime
+	// contract in runtime.go), which is podman's default with All unset.
+	// WithAll(true) is only needed to surface other states for the status
+	// filter below.
+	opts := new(containers.ListOptions)
+	if len(statuses) > 0 {
+		opts = opts.WithAll(true).WithFilters(map[string][]string{"status": statuses})
+	}
+	list, err := containers.List(p.conn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dockerapi.APIContainers, 0, len(list))
+	for _, c := range list {
+		result = append(result, dockerapi.APIContainers{
+			ID:      c.ID,
+			Image:   c.Image,
+			Names:   prefixNames(c.Names),
+			Created: c.Created.Unix(),
+			Status:  c.State,
+		})
+	}
+	return result, nil
+}
+
+func (p *podmanRuntime) InspectContainer(id string) (*dockerapi.Container, error) {
+	data, err := containers.Inspect(p.conn, id, nil)
+	if err != nil {
+		if errors.Is(err, define.ErrNoSuchCtr) {
+			return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, err)
+		}
+		return nil, err
+	}
+	return podmanToDockerContainer(data), nil
+}
+
+func (p *podmanRuntime) AddEventListener(listener chan *dockerapi.APIEvents) error {
+	stream := make(chan entities.Event)
+	streaming := true
+	go func() {
+		system.Events(p.conn, entities.EventsOptions{
+			EventChan: stream,
+			Stream:    &streaming,
+		})
+	}()
+	go func() {
+		for event := range stream {
+			listener <- &dockerapi.APIEvents{
+				Status: string(event.Status),
+				ID:     event.Actor.ID,
+				From:   event.Image,
+				Type:   string(event.Type),
+				Action: string(event.Action),
+				Time:   event.Time.Unix(),
+				Actor: dockerapi.APIActor{
+					ID:         event.Actor.ID,
+					Attributes: event.Actor.Attributes,
+				},
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *podmanRuntime) RemoveEventListener(listener chan *dockerapi.APIEvents) error {
+	// The podman bindings stream has no per-listener unsubscribe; the event
+	// goroutine started in AddEventListener exits once the daemon closes the
+	// connection on process shutdown.
+	return nil
+}
+
+// prefixNames matches go-dockerclient's convention of leading container
+// names with a slash.
+func prefixNames(names []string) []string {
+	prefixed := make([]string, len(names))
+	for i, name := range names {
+		if strings.HasPrefix(name, "/") {
+			prefixed[i] = name
+		} else {
+			prefixed[i] = "/" + name
+		}
+	}
+	return prefixed
+}
+
+// podmanToDockerContainer translates a Podman inspect response into the
+// subset of dockerapi.Container fields Bridge relies on: Config.ExposedPorts,
+// Config.Hostname/Image/Labels/Cmd, HostConfig.NetworkMode,
+// NetworkSettings.Ports and NetworkSettings.Networks.
+func podmanToDockerContainer(data *define.InspectContainerData) *dockerapi.Container {
+	container := &dockerapi.Container{
+		ID:   data.ID,
+		Name: "/" + strings.TrimPrefix(data.Name, "/"),
+		Config: &dockerapi.Config{
+			Hostname:     data.Config.Hostname,
+			Image:        data.ImageName,
+			Labels:       data.Config.Labels,
+			Cmd:          data.Config.Cmd,
+			ExposedPorts: make(map[dockerapi.Port]struct{}),
+		},
+		HostConfig: &dockerapi.HostConfig{
+			NetworkMode: string(data.HostConfig.NetworkMode),
+		},
+		NetworkSettings: &dockerapi.NetworkSettings{
+			Ports:    make(map[dockerapi.Port][]dockerapi.PortBinding),
+			Networks: make(map[string]dockerapi.ContainerNetwork),
+		},
+		State: dockerapi.State{
+			Running:  data.State.Running,
+			ExitCode: data.State.ExitCode,
+		},
+	}
+
+	// ExposedPorts comes from the container's own port declarations, not from
+	// NetworkSettings.Ports: a --net=host container exposes ports without
+	// publishing them, and add()'s first port loop relies on ExposedPorts
+	// alone to catch that case.
+	for portProto := range data.Config.ExposedPorts {
+		container.Config.ExposedPorts[dockerapi.Port(portProto)] = struct{}{}
+	}
+
+	for portProto, bindings := range data.NetworkSettings.Ports {
+		port := dockerapi.Port(portProto)
+		for _, b := range bindings {
+			container.NetworkSettings.Ports[port] = append(container.NetworkSettings.Ports[port], dockerapi.PortBinding{
+				HostIP:   b.HostIP,
+				HostPort: b.HostPort,
+			})
+		}
+	}
+
+	for name, net := range data.NetworkSettings.Networks {
+		container.NetworkSettings.Networks[name] = dockerapi.ContainerNetwork{
+			NetworkID:  net.NetworkID,
+			IPAddress:  net.IPAddress,
+			Gateway:    net.Gateway,
+			MacAddress: net.MacAddress,
+		}
+	}
+
+	return container
+}